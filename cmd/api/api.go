@@ -29,6 +29,9 @@ type Config struct {
 	BuildingTableName string `envconfig:"building_table_name" default:"liszt-buildings-dev"`
 	UnitTableName     string `envconfig:"unit_table_name" default:"liszt-units-dev"`
 	ResidentTableName string `envconfig:"resident_table_name" default:"liszt-residents-dev"`
+
+	DefaultBuildingID     string `envconfig:"default_building_id"`
+	CreateDefaultBuilding bool   `envconfig:"create_default_building"`
 }
 
 type panicLogger struct {
@@ -60,6 +63,13 @@ func main() {
 		logger.Fatal(err)
 	}
 
+	if cfg.DefaultBuildingID != "" {
+		_, err = registry.ParseBuildingID(cfg.DefaultBuildingID)
+		if err != nil {
+			logger.Fatalf("invalid default_building_id: %s", err)
+		}
+	}
+
 	sess := session.New(aws.NewConfig().WithRegion(cfg.AWSRegion))
 	registrar := &registry.DynamoRegistrar{
 		DB: dynamodb.New(sess),
@@ -67,6 +77,9 @@ func main() {
 			BuildingTableName: cfg.BuildingTableName,
 			UnitTableName:     cfg.UnitTableName,
 			ResidentTableName: cfg.ResidentTableName,
+
+			DefaultBuildingID:     cfg.DefaultBuildingID,
+			CreateDefaultBuilding: cfg.CreateDefaultBuilding,
 		},
 	}
 
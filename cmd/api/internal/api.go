@@ -13,10 +13,13 @@ func NewCRUDService(registrar registry.Registrar) (mux *chi.Mux) {
 	mux.Post("/buildings/deregister", svc.DeregisterBuilding)
 	mux.Post("/units/register", svc.RegisterUnit)
 	mux.Post("/units/deregister", svc.DeregisterUnit)
+	mux.Get("/units", svc.ListUnitsByOccupancy)
+	mux.Post("/units/occupancy/batch", svc.GetOccupancyForUnits)
 	mux.Post("/residents/register", svc.RegisterResident)
 	mux.Post("/residents/deregister", svc.DeregisterResident)
 	mux.Post("/residents/move_in", svc.MoveResidentIn)
 	mux.Post("/residents/move_out", svc.MoveResidentOut)
+	mux.Post("/admin/residents/diff", svc.DiffResidents)
 	return
 }
 
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/liszt-code/liszt/pkg/registry"
+	"github.com/liszt-code/liszt/pkg/registry/mocks"
+	"github.com/liszt-code/liszt/pkg/registry/resolver"
+	"github.com/liszt-code/liszt/pkg/registry/schema"
+	graphql "github.com/neelance/graphql-go"
+	"github.com/neelance/graphql-go/relay"
+	"github.com/oklog/ulid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMalformedIDs(t *testing.T) {
+	validID := ulid.MustNew(ulid.Now(), nil).String()
+
+	for _, path := range []string{
+		"/buildings/deregister",
+		"/buildings/deregister?building_id=12345",
+		"/buildings/deregister?building_id=" + validID + "0",
+		"/units/register?building_id=nonexistent",
+		"/units/deregister",
+		"/units/deregister?unit_id=12345",
+		"/units/deregister?unit_id=" + validID[:25] + "U",
+		"/residents/deregister",
+		"/residents/deregister?resident_id=12345",
+		"/residents/move_in?resident_id=12345&unit_id=" + validID,
+		"/residents/move_in?resident_id=" + validID + "&unit_id=12345",
+		"/residents/move_out?resident_id=" + validID,
+		"/residents/move_out?resident_id=" + validID + "&unit_id=8" + validID[1:],
+	} {
+		t.Run(path, func(t *testing.T) {
+			registrar := new(mocks.Registrar)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString("{}"))
+			NewCRUDService(registrar).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Empty(t, registrar.Calls)
+		})
+	}
+}
+
+func TestGraphQLMalformedBuildingID(t *testing.T) {
+	gqlSchema, err := schema.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registrar := new(mocks.Registrar)
+	scheme, err := graphql.ParseSchema(gqlSchema, &resolver.Resolver{
+		Registrar: registrar,
+		Logger:    logrus.New(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/query",
+		bytes.NewBufferString(`{"query":"{ Building(BuildingID: \"12345\") { id } }"}`))
+	(&relay.Handler{Schema: scheme}).ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), "building_id is malformed")
+	assert.Empty(t, registrar.Calls)
+}
+
+func TestGetOccupancyForUnits(t *testing.T) {
+	unitIDs := []string{
+		ulid.MustNew(ulid.Now(), nil).String(),
+		ulid.MustNew(ulid.Now()+1, nil).String(),
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		registrar := new(mocks.Registrar)
+		registrar.On("GetOccupancyForUnits", mock.Anything, unitIDs).Return(map[string]int64{
+			unitIDs[0]: 3,
+			unitIDs[1]: 0,
+		}, nil)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/units/occupancy/batch",
+			bytes.NewBufferString(`{"UnitIDs":["`+unitIDs[0]+`","`+unitIDs[1]+`"]}`))
+		NewCRUDService(registrar).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"`+unitIDs[0]+`":3,"`+unitIDs[1]+`":0}`, w.Body.String())
+		registrar.AssertExpectations(t)
+	})
+
+	t.Run("oversized batch", func(t *testing.T) {
+		ids := make([]string, maxOccupancyBatchSize+1)
+		for i := range ids {
+			ids[i] = unitIDs[0]
+		}
+		body, err := json.Marshal(map[string][]string{"UnitIDs": ids})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		registrar := new(mocks.Registrar)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/units/occupancy/batch", bytes.NewBuffer(body))
+		NewCRUDService(registrar).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, registrar.Calls)
+	})
+
+	t.Run("malformed unit id", func(t *testing.T) {
+		registrar := new(mocks.Registrar)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/units/occupancy/batch",
+			bytes.NewBufferString(`{"UnitIDs":["`+unitIDs[0]+`","12345"]}`))
+		NewCRUDService(registrar).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, registrar.Calls)
+	})
+}
+
+func TestListUnitsByOccupancy(t *testing.T) {
+	units := []*registry.UnitStatus{{
+		Unit:      registry.Unit{ID: ulid.MustNew(ulid.Now(), nil).String(), Capacity: 4},
+		Residents: 4,
+		Occupancy: 100,
+	}}
+
+	for _, tc := range []struct {
+		query          string
+		minPct, maxPct float64
+	}{
+		{"", 0, math.Inf(1)},
+		{"?min_occupancy=80", 80, math.Inf(1)},
+		{"?max_occupancy=0", 0, 0},
+		{"?min_occupancy=80&max_occupancy=100", 80, 100},
+		{"?min_occupancy=50&max_occupancy=50", 50, 50},
+	} {
+		t.Run("range "+tc.query, func(t *testing.T) {
+			registrar := new(mocks.Registrar)
+			registrar.On("ListUnitsByOccupancy", mock.Anything, tc.minPct, tc.maxPct).Return(units, nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/units"+tc.query, nil)
+			NewCRUDService(registrar).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var output []*registry.UnitStatus
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &output))
+			assert.Equal(t, units, output)
+			registrar.AssertExpectations(t)
+		})
+	}
+
+	for _, query := range []string{
+		"?min_occupancy=eighty",
+		"?max_occupancy=full",
+		"?min_occupancy=NaN",
+		"?max_occupancy=nan",
+		"?min_occupancy=-10",
+		"?min_occupancy=80&max_occupancy=20",
+	} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			registrar := new(mocks.Registrar)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/units"+query, nil)
+			NewCRUDService(registrar).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Empty(t, registrar.Calls)
+		})
+	}
+}
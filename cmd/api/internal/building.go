@@ -42,13 +42,13 @@ func (svc *apiserver) RegisterBuilding(w http.ResponseWriter, r *http.Request) {
 
 // DeregisterBuilding deregisters a building
 func (svc *apiserver) DeregisterBuilding(w http.ResponseWriter, r *http.Request) {
-	buildingID := r.URL.Query().Get("building_id")
-	if buildingID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "building_id is required"))
+	buildingID, err := registry.ParseBuildingID(r.URL.Query().Get("building_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	err := svc.registrar.DeregisterBuilding(r.Context(), buildingID)
+	err = svc.registrar.DeregisterBuilding(r.Context(), buildingID)
 	if err != nil {
 		apiutils.WriteError(w, err)
 		return
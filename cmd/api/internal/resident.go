@@ -3,6 +3,7 @@ package internal
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/bsdlp/apiutils"
 	"github.com/liszt-code/liszt/pkg/registry"
@@ -40,13 +41,61 @@ func (svc *apiserver) RegisterResident(w http.ResponseWriter, r *http.Request) {
 
 // DeregisterResident deregisters a resident
 func (svc *apiserver) DeregisterResident(w http.ResponseWriter, r *http.Request) {
-	residentID := r.URL.Query().Get("resident_id")
-	if residentID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "resident_id is required"))
+	residentID, err := registry.ParseResidentID(r.URL.Query().Get("resident_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
+		return
+	}
+
+	err = svc.registrar.DeregisterResident(r.Context(), residentID)
+	if err != nil {
+		apiutils.WriteError(w, err)
+		return
+	}
+	return
+}
+
+const (
+	defaultDiffPageSize = 100
+	maxDiffPageSize     = 1000
+)
+
+// DiffResidents returns a page of differences against an external snapshot
+func (svc *apiserver) DiffResidents(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			// do nothing
+		}
+	}()
+
+	// cursor from the previous page's Next, empty for the first page
+	after := r.URL.Query().Get("after")
+
+	limit := defaultDiffPageSize
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > maxDiffPageSize {
+			apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxDiffPageSize)))
+			return
+		}
+	}
+
+	var input []*registry.Resident
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	diff, err := svc.registrar.DiffResidents(r.Context(), input)
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	err := svc.registrar.DeregisterResident(r.Context(), residentID)
+	err = apiutils.WriteJSON(w, diff.Page(after, limit))
 	if err != nil {
 		apiutils.WriteError(w, err)
 		return
@@ -56,19 +105,19 @@ func (svc *apiserver) DeregisterResident(w http.ResponseWriter, r *http.Request)
 
 // MoveResidentIn moves a resident into a unit
 func (svc *apiserver) MoveResidentIn(w http.ResponseWriter, r *http.Request) {
-	residentID := r.URL.Query().Get("resident_id")
-	if residentID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "resident_id is required"))
+	residentID, err := registry.ParseResidentID(r.URL.Query().Get("resident_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	unitID := r.URL.Query().Get("unit_id")
-	if unitID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "unit_id is required"))
+	unitID, err := registry.ParseUnitID(r.URL.Query().Get("unit_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	err := svc.registrar.MoveResidentIn(r.Context(), residentID, unitID)
+	err = svc.registrar.MoveResidentIn(r.Context(), residentID, unitID)
 	if err != nil {
 		apiutils.WriteError(w, err)
 		return
@@ -78,19 +127,19 @@ func (svc *apiserver) MoveResidentIn(w http.ResponseWriter, r *http.Request) {
 
 // MoveResidentOut moves a resident out of a unit
 func (svc *apiserver) MoveResidentOut(w http.ResponseWriter, r *http.Request) {
-	residentID := r.URL.Query().Get("resident_id")
-	if residentID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "resident_id is required"))
+	residentID, err := registry.ParseResidentID(r.URL.Query().Get("resident_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	unitID := r.URL.Query().Get("unit_id")
-	if unitID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "unit_id is required"))
+	unitID, err := registry.ParseUnitID(r.URL.Query().Get("unit_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	err := svc.registrar.MoveResidentOut(r.Context(), residentID, unitID)
+	err = svc.registrar.MoveResidentOut(r.Context(), residentID, unitID)
 	if err != nil {
 		apiutils.WriteError(w, err)
 		return
@@ -2,7 +2,10 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/bsdlp/apiutils"
 	"github.com/liszt-code/liszt/pkg/registry"
@@ -24,10 +27,14 @@ func (svc *apiserver) RegisterUnit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the registrar decides whether a missing building falls back to a default
 	buildingID := r.URL.Query().Get("building_id")
-	if buildingID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "building_id is required"))
-		return
+	if buildingID != "" {
+		buildingID, err = registry.ParseBuildingID(buildingID)
+		if err != nil {
+			apiutils.WriteError(w, err)
+			return
+		}
 	}
 
 	output, err := svc.registrar.RegisterUnit(r.Context(), buildingID, input)
@@ -46,16 +53,111 @@ func (svc *apiserver) RegisterUnit(w http.ResponseWriter, r *http.Request) {
 
 // DeregisterUnit deregisters a unit
 func (svc *apiserver) DeregisterUnit(w http.ResponseWriter, r *http.Request) {
-	unitID := r.URL.Query().Get("unit_id")
-	if unitID == "" {
-		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "unit_id is required"))
+	unitID, err := registry.ParseUnitID(r.URL.Query().Get("unit_id"))
+	if err != nil {
+		apiutils.WriteError(w, err)
 		return
 	}
 
-	err := svc.registrar.DeregisterUnit(r.Context(), unitID)
+	err = svc.registrar.DeregisterUnit(r.Context(), unitID)
 	if err != nil {
 		apiutils.WriteError(w, err)
 		return
 	}
 	return
 }
+
+// ListUnitsByOccupancy lists units whose occupancy falls within a range
+func (svc *apiserver) ListUnitsByOccupancy(w http.ResponseWriter, r *http.Request) {
+	minPct, err := parseOccupancy(r.URL.Query().Get("min_occupancy"), 0)
+	if err != nil {
+		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "min_occupancy must be a number"))
+		return
+	}
+
+	// units can be over capacity, so there is no upper bound by default
+	maxPct, err := parseOccupancy(r.URL.Query().Get("max_occupancy"), math.Inf(1))
+	if err != nil {
+		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "max_occupancy must be a number"))
+		return
+	}
+
+	if minPct < 0 || minPct > maxPct {
+		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "invalid occupancy range"))
+		return
+	}
+
+	output, err := svc.registrar.ListUnitsByOccupancy(r.Context(), minPct, maxPct)
+	if err != nil {
+		apiutils.WriteError(w, err)
+		return
+	}
+
+	err = apiutils.WriteJSON(w, output)
+	if err != nil {
+		apiutils.WriteError(w, err)
+		return
+	}
+	return
+}
+
+// maxOccupancyBatchSize bounds how many units one request can look up,
+// enough for a building dashboard
+const maxOccupancyBatchSize = 500
+
+// GetOccupancyForUnits returns resident counts for a batch of units
+func (svc *apiserver) GetOccupancyForUnits(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			// do nothing
+		}
+	}()
+
+	input := new(struct {
+		UnitIDs []string
+	})
+	err := json.NewDecoder(r.Body).Decode(input)
+	if err != nil {
+		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if len(input.UnitIDs) > maxOccupancyBatchSize {
+		apiutils.WriteError(w, apiutils.NewError(http.StatusBadRequest, "at most "+strconv.Itoa(maxOccupancyBatchSize)+" unit ids per batch"))
+		return
+	}
+
+	for _, v := range input.UnitIDs {
+		_, err = registry.ParseUnitID(v)
+		if err != nil {
+			apiutils.WriteError(w, err)
+			return
+		}
+	}
+
+	output, err := svc.registrar.GetOccupancyForUnits(r.Context(), input.UnitIDs)
+	if err != nil {
+		apiutils.WriteError(w, err)
+		return
+	}
+
+	err = apiutils.WriteJSON(w, output)
+	if err != nil {
+		apiutils.WriteError(w, err)
+		return
+	}
+	return
+}
+
+func parseOccupancy(s string, def float64) (pct float64, err error) {
+	if s == "" {
+		pct = def
+		return
+	}
+	pct, err = strconv.ParseFloat(s, 64)
+	if err == nil && math.IsNaN(pct) {
+		err = errors.New("occupancy is not a number")
+	}
+	return
+}
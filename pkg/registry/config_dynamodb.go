@@ -5,4 +5,11 @@ type DynamoConfig struct {
 	BuildingTableName string
 	UnitTableName     string
 	ResidentTableName string
+
+	// DefaultBuildingID is the building that units registered without one are
+	// assigned to. If empty, registering a unit requires a building. It must
+	// be a valid building id, see ParseBuildingID.
+	DefaultBuildingID string
+	// CreateDefaultBuilding creates the default building on first use
+	CreateDefaultBuilding bool
 }
@@ -1,11 +1,20 @@
 package registry
 
-import "github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
 
 // DynamoRegistrar implements Registrar using dynamodb
 type DynamoRegistrar struct {
 	DB     dynamodbiface.DynamoDBAPI
 	Config *DynamoConfig
+
+	// guards creating the default building, which is done at most once
+	defaultBuildingMu      sync.Mutex
+	defaultBuildingCreated bool
 }
 
 const (
@@ -13,4 +22,14 @@ const (
 	unitIDAttributeName     = "unit_id"
 	residentIDAttributeName = "resident_id"
 	buildingUnitsGSIName    = "building_unit_gsi"
+
+	defaultBuildingName = "Unassigned"
+
+	// maximum number of keys dynamodb accepts in a single BatchGetItem
+	batchGetItemLimit = 100
+
+	// unprocessed keys mean dynamodb is throttling, so retries back off
+	// exponentially starting from batchGetRetryDelay
+	batchGetMaxAttempts = 5
+	batchGetRetryDelay  = 50 * time.Millisecond
 )
@@ -52,6 +52,29 @@ func (_m *Registrar) DeregisterUnit(ctx context.Context, unitID string) error {
 	return r0
 }
 
+// DiffResidents provides a mock function with given fields: ctx, external
+func (_m *Registrar) DiffResidents(ctx context.Context, external []*registry.Resident) (*registry.ReconcileDiff, error) {
+	ret := _m.Called(ctx, external)
+
+	var r0 *registry.ReconcileDiff
+	if rf, ok := ret.Get(0).(func(context.Context, []*registry.Resident) *registry.ReconcileDiff); ok {
+		r0 = rf(ctx, external)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*registry.ReconcileDiff)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*registry.Resident) error); ok {
+		r1 = rf(ctx, external)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetBuildingByID provides a mock function with given fields: ctx, buildingID
 func (_m *Registrar) GetBuildingByID(ctx context.Context, buildingID string) (*registry.Building, error) {
 	ret := _m.Called(ctx, buildingID)
@@ -75,6 +98,29 @@ func (_m *Registrar) GetBuildingByID(ctx context.Context, buildingID string) (*r
 	return r0, r1
 }
 
+// GetOccupancyForUnits provides a mock function with given fields: ctx, unitIDs
+func (_m *Registrar) GetOccupancyForUnits(ctx context.Context, unitIDs []string) (map[string]int64, error) {
+	ret := _m.Called(ctx, unitIDs)
+
+	var r0 map[string]int64
+	if rf, ok := ret.Get(0).(func(context.Context, []string) map[string]int64); ok {
+		r0 = rf(ctx, unitIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, unitIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetResidentByID provides a mock function with given fields: ctx, residentID
 func (_m *Registrar) GetResidentByID(ctx context.Context, residentID string) (*registry.Resident, error) {
 	ret := _m.Called(ctx, residentID)
@@ -167,6 +213,29 @@ func (_m *Registrar) ListUnitResidents(ctx context.Context, unitID string) ([]*r
 	return r0, r1
 }
 
+// ListUnitsByOccupancy provides a mock function with given fields: ctx, minPct, maxPct
+func (_m *Registrar) ListUnitsByOccupancy(ctx context.Context, minPct float64, maxPct float64) ([]*registry.UnitStatus, error) {
+	ret := _m.Called(ctx, minPct, maxPct)
+
+	var r0 []*registry.UnitStatus
+	if rf, ok := ret.Get(0).(func(context.Context, float64, float64) []*registry.UnitStatus); ok {
+		r0 = rf(ctx, minPct, maxPct)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*registry.UnitStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, float64, float64) error); ok {
+		r1 = rf(ctx, minPct, maxPct)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // MoveResidentIn provides a mock function with given fields: ctx, residentID, newUnitID
 func (_m *Registrar) MoveResidentIn(ctx context.Context, residentID string, newUnitID string) error {
 	ret := _m.Called(ctx, residentID, newUnitID)
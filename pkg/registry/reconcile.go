@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/bsdlp/apiutils"
+)
+
+// ReconcileDiff describes how the registry differs from an external snapshot
+// of residents. Every list is sorted by ExternalID.
+type ReconcileDiff struct {
+	// OnlyInRegistry holds registry residents with no external counterpart,
+	// including those without an ExternalID
+	OnlyInRegistry []*Resident
+	OnlyInExternal []*Resident
+	Differing      []*ResidentDiff
+
+	// Total is the number of records in the whole diff, not just this page
+	Total int
+	// Next is the cursor to pass to Page for the following page
+	Next string
+}
+
+// ResidentDiff pairs a registry resident with its external counterpart
+type ResidentDiff struct {
+	ExternalID string
+	Registry   *Resident
+	External   *Resident
+
+	// Fields lists the names of the fields that differ
+	Fields []string
+}
+
+// Page returns at most limit records of the diff that come after the cursor
+// after, in reconcile key order across all three lists. Next is set to the
+// cursor for the following page, or left empty on the last page. Because the
+// cursor is a key rather than a position, records that drop out of the diff
+// between requests do not shift later pages.
+func (d *ReconcileDiff) Page(after string, limit int) (page *ReconcileDiff) {
+	page = &ReconcileDiff{
+		OnlyInRegistry: []*Resident{},
+		OnlyInExternal: []*Resident{},
+		Differing:      []*ResidentDiff{},
+		Total:          d.Total,
+	}
+
+	var i, j, k, n int
+	var last string
+	for {
+		next, key := -1, ""
+		if i < len(d.OnlyInRegistry) {
+			next, key = 0, reconcileKey(d.OnlyInRegistry[i])
+		}
+		if j < len(d.OnlyInExternal) && (next < 0 || reconcileKey(d.OnlyInExternal[j]) < key) {
+			next, key = 1, reconcileKey(d.OnlyInExternal[j])
+		}
+		if k < len(d.Differing) && (next < 0 || reconcileKey(d.Differing[k].Registry) < key) {
+			next, key = 2, reconcileKey(d.Differing[k].Registry)
+		}
+		if next < 0 {
+			return
+		}
+
+		take := key > after
+		if take && n == limit {
+			page.Next = last
+			return
+		}
+
+		switch next {
+		case 0:
+			if take {
+				page.OnlyInRegistry = append(page.OnlyInRegistry, d.OnlyInRegistry[i])
+			}
+			i++
+		case 1:
+			if take {
+				page.OnlyInExternal = append(page.OnlyInExternal, d.OnlyInExternal[j])
+			}
+			j++
+		case 2:
+			if take {
+				page.Differing = append(page.Differing, d.Differing[k])
+			}
+			k++
+		}
+
+		if take {
+			n++
+			last = key
+		}
+	}
+}
+
+// reconcileKey orders residents by ExternalID, falling back to ID for
+// registry residents that have no ExternalID
+func reconcileKey(res *Resident) string {
+	return res.ExternalID + "\x00" + res.ID
+}
+
+func diffResidents(registry, external []*Resident) (diff *ReconcileDiff, err error) {
+	externalByID := make(map[string]*Resident, len(external))
+	for _, v := range external {
+		if v == nil || v.ExternalID == "" {
+			err = apiutils.NewError(http.StatusBadRequest, "external residents require an external_id")
+			return
+		}
+		if _, ok := externalByID[v.ExternalID]; ok {
+			err = apiutils.NewError(http.StatusBadRequest, "duplicate external_id in snapshot: "+v.ExternalID)
+			return
+		}
+		externalByID[v.ExternalID] = v
+	}
+
+	diff = &ReconcileDiff{
+		OnlyInRegistry: []*Resident{},
+		OnlyInExternal: []*Resident{},
+		Differing:      []*ResidentDiff{},
+	}
+
+	matched := make(map[string]bool, len(registry))
+	for _, v := range registry {
+		if v.ExternalID == "" {
+			diff.OnlyInRegistry = append(diff.OnlyInRegistry, v)
+			continue
+		}
+		if matched[v.ExternalID] {
+			diff = nil
+			err = apiutils.NewError(http.StatusConflict, "duplicate external_id in registry: "+v.ExternalID)
+			return
+		}
+		matched[v.ExternalID] = true
+
+		ext, ok := externalByID[v.ExternalID]
+		if !ok {
+			diff.OnlyInRegistry = append(diff.OnlyInRegistry, v)
+			continue
+		}
+
+		fields := residentFieldDiff(v, ext)
+		if len(fields) > 0 {
+			diff.Differing = append(diff.Differing, &ResidentDiff{
+				ExternalID: v.ExternalID,
+				Registry:   v,
+				External:   ext,
+				Fields:     fields,
+			})
+		}
+	}
+
+	for _, v := range external {
+		if !matched[v.ExternalID] {
+			diff.OnlyInExternal = append(diff.OnlyInExternal, v)
+		}
+	}
+
+	sort.Slice(diff.OnlyInRegistry, func(i, j int) bool {
+		return reconcileKey(diff.OnlyInRegistry[i]) < reconcileKey(diff.OnlyInRegistry[j])
+	})
+	sort.Slice(diff.OnlyInExternal, func(i, j int) bool {
+		return reconcileKey(diff.OnlyInExternal[i]) < reconcileKey(diff.OnlyInExternal[j])
+	})
+	sort.Slice(diff.Differing, func(i, j int) bool {
+		return reconcileKey(diff.Differing[i].Registry) < reconcileKey(diff.Differing[j].Registry)
+	})
+	diff.Total = len(diff.OnlyInRegistry) + len(diff.OnlyInExternal) + len(diff.Differing)
+	return
+}
+
+func residentFieldDiff(a, b *Resident) (fields []string) {
+	if a.Firstname != b.Firstname {
+		fields = append(fields, "Firstname")
+	}
+	if a.Middlename != b.Middlename {
+		fields = append(fields, "Middlename")
+	}
+	if a.Lastname != b.Lastname {
+		fields = append(fields, "Lastname")
+	}
+	return
+}
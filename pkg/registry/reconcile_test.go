@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bsdlp/apiutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffResidents(t *testing.T) {
+	registry := []*Resident{
+		{ID: "1", ExternalID: "c", Firstname: "Josiah", Lastname: "Bartlet"},
+		{ID: "2", ExternalID: "a", Firstname: "Leo", Lastname: "McGarry"},
+		{ID: "3", ExternalID: "b", Firstname: "Toby", Lastname: "Ziegler"},
+		{ID: "4", Firstname: "Sam", Lastname: "Seaborn"},
+	}
+	external := []*Resident{
+		{ExternalID: "e", Firstname: "Donna", Lastname: "Moss"},
+		{ExternalID: "c", Firstname: "Josiah", Lastname: "Bartlet"},
+		{ExternalID: "b", Firstname: "Tobias", Lastname: "Ziegler"},
+		{ExternalID: "d", Firstname: "Josh", Lastname: "Lyman"},
+	}
+
+	t.Run("diff", func(t *testing.T) {
+		assert := assert.New(t)
+		diff, err := diffResidents(registry, external)
+		if !assert.NoError(err) {
+			return
+		}
+
+		assert.Equal([]*Resident{registry[3], registry[1]}, diff.OnlyInRegistry)
+		assert.Equal([]*Resident{external[3], external[0]}, diff.OnlyInExternal)
+		if assert.Len(diff.Differing, 1) {
+			assert.Equal(&ResidentDiff{
+				ExternalID: "b",
+				Registry:   registry[2],
+				External:   external[2],
+				Fields:     []string{"Firstname"},
+			}, diff.Differing[0])
+		}
+		assert.Equal(5, diff.Total)
+	})
+
+	t.Run("pages are ordered by external id", func(t *testing.T) {
+		assert := assert.New(t)
+		diff, err := diffResidents(registry, external)
+		if !assert.NoError(err) {
+			return
+		}
+
+		page := diff.Page("", 2)
+		assert.Equal([]*Resident{registry[3], registry[1]}, page.OnlyInRegistry)
+		assert.Empty(page.OnlyInExternal)
+		assert.Empty(page.Differing)
+		assert.Equal(5, page.Total)
+		assert.Equal(reconcileKey(registry[1]), page.Next)
+
+		page = diff.Page(page.Next, 2)
+		assert.Empty(page.OnlyInRegistry)
+		assert.Equal([]*Resident{external[3]}, page.OnlyInExternal)
+		assert.Len(page.Differing, 1)
+		assert.Equal(reconcileKey(external[3]), page.Next)
+
+		page = diff.Page(page.Next, 2)
+		assert.Equal([]*Resident{external[0]}, page.OnlyInExternal)
+		assert.Empty(page.OnlyInRegistry)
+		assert.Empty(page.Differing)
+		assert.Empty(page.Next, "last page has no cursor")
+	})
+
+	t.Run("records fixed between pages do not shift later pages", func(t *testing.T) {
+		assert := assert.New(t)
+		diff, err := diffResidents(registry, external)
+		if !assert.NoError(err) {
+			return
+		}
+
+		first := diff.Page("", 2)
+		assert.Equal([]*Resident{registry[3], registry[1]}, first.OnlyInRegistry)
+
+		// the workflow deregisters a resident it has already seen, and a new
+		// resident without an external id is registered meanwhile
+		fixed := []*Resident{
+			{ID: "0", Firstname: "Charlie", Lastname: "Young"},
+			registry[0],
+			registry[2],
+			registry[3],
+		}
+		diff, err = diffResidents(fixed, external)
+		if !assert.NoError(err) {
+			return
+		}
+
+		second := diff.Page(first.Next, 2)
+		assert.Empty(second.OnlyInRegistry, "records before the cursor are not repeated")
+		if assert.Len(second.Differing, 1) {
+			assert.Equal(registry[2], second.Differing[0].Registry)
+		}
+		assert.Equal([]*Resident{external[3]}, second.OnlyInExternal)
+
+		third := diff.Page(second.Next, 2)
+		assert.Equal([]*Resident{external[0]}, third.OnlyInExternal)
+		assert.Empty(third.Next)
+	})
+
+	t.Run("external residents require an external id", func(t *testing.T) {
+		_, err := diffResidents(registry, []*Resident{{Firstname: "Donna"}})
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(apiutils.Error).StatusCode())
+		}
+	})
+
+	t.Run("duplicate external ids in snapshot", func(t *testing.T) {
+		_, err := diffResidents(registry, []*Resident{external[0], external[0]})
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(apiutils.Error).StatusCode())
+		}
+	})
+
+	t.Run("duplicate external ids in registry", func(t *testing.T) {
+		_, err := diffResidents([]*Resident{registry[0], registry[0]}, external)
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusConflict, err.(apiutils.Error).StatusCode())
+		}
+	})
+}
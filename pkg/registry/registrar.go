@@ -14,13 +14,23 @@ type Registrar interface {
 
 	ListBuildingUnits(ctx context.Context, buildingID string) (units []*Unit, err error)
 
-	// register unit
+	// register unit. if buildingID is empty the unit is assigned to the
+	// configured default building, if any.
 	RegisterUnit(ctx context.Context, buildingID string, in *Unit) (unit *Unit, err error)
 
 	DeregisterUnit(ctx context.Context, unitID string) (err error)
 
+	// lists units whose occupancy, as a percentage of capacity, falls within
+	// minPct and maxPct inclusive. units without a capacity are unlimited and
+	// are never returned.
+	ListUnitsByOccupancy(ctx context.Context, minPct, maxPct float64) (units []*UnitStatus, err error)
+
 	ListUnitResidents(ctx context.Context, unitID string) (residents []*Resident, err error)
 
+	// returns the number of residents in each of the given units. units that
+	// do not exist are left out.
+	GetOccupancyForUnits(ctx context.Context, unitIDs []string) (occupancy map[string]int64, err error)
+
 	GetResidentByID(ctx context.Context, residentID string) (resident *Resident, err error)
 
 	// adds a resident into the registry, optionally attaching the resident to
@@ -29,6 +39,10 @@ type Registrar interface {
 
 	DeregisterResident(ctx context.Context, residentID string) (err error)
 
+	// compares the residents in the registry against an external snapshot,
+	// matching them up by ExternalID. the registry is not modified.
+	DiffResidents(ctx context.Context, external []*Resident) (diff *ReconcileDiff, err error)
+
 	// moves a resident to a new unit
 	MoveResidentIn(ctx context.Context, residentID, newUnitID string) (err error)
 
@@ -47,6 +61,9 @@ type Building struct {
 type Resident struct {
 	ID string `dynamodbav:"resident_id"`
 
+	// ExternalID identifies the resident in the property management system
+	ExternalID string
+
 	Firstname  string
 	Middlename string
 	Lastname   string
@@ -60,4 +77,17 @@ func (res *Resident) String() string {
 type Unit struct {
 	ID   string
 	Name string
+
+	// Capacity is the maximum number of residents, zero means unlimited
+	Capacity int64
+}
+
+// UnitStatus describes a unit along with its occupancy
+type UnitStatus struct {
+	Unit
+	BuildingID string
+
+	Residents int64
+	// Occupancy is the percentage of capacity taken up by residents
+	Occupancy float64
 }
@@ -75,6 +75,44 @@ func (dr *DynamoRegistrar) DeregisterResident(ctx context.Context, residentID st
 	return
 }
 
+// DiffResidents implements Registrar. It scans the whole residents table,
+// since the table is keyed by resident_id and cannot be read in ExternalID
+// order.
+func (dr *DynamoRegistrar) DiffResidents(ctx context.Context, external []*Resident) (diff *ReconcileDiff, err error) {
+	residents, err := dr.scanResidents(ctx)
+	if err != nil {
+		return
+	}
+
+	diff, err = diffResidents(residents, external)
+	return
+}
+
+func (dr *DynamoRegistrar) scanResidents(ctx context.Context) (residents []*Resident, err error) {
+	residents = []*Resident{}
+	var unmarshalErr error
+	err = dr.DB.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName: aws.String(dr.Config.ResidentTableName),
+	}, func(out *dynamodb.ScanOutput, lastPage bool) bool {
+		page := make([]*Resident, aws.Int64Value(out.Count))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(out.Items, &page)
+		if unmarshalErr != nil {
+			return false
+		}
+		residents = append(residents, page...)
+		return true
+	})
+	if err == nil {
+		err = unmarshalErr
+	}
+	if err != nil {
+		residents = nil
+		err = errors.WithStack(err)
+		return
+	}
+	return
+}
+
 func (dr *DynamoRegistrar) batchGetResidents(ctx context.Context, residentIDs []string) (residents []*Resident, err error) {
 	if residentIDs == nil || len(residentIDs) == 0 {
 		residents = []*Resident{}
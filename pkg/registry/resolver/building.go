@@ -9,10 +9,16 @@ import (
 )
 
 // Building retrieves a building by ID
-func (r *Resolver) Building(args struct{ BuildingID graphql.ID }) (br *BuildingResolver) {
-	building, err := r.Registrar.GetBuildingByID(context.TODO(), string(args.BuildingID))
+func (r *Resolver) Building(args struct{ BuildingID graphql.ID }) (br *BuildingResolver, err error) {
+	buildingID, err := registry.ParseBuildingID(string(args.BuildingID))
+	if err != nil {
+		return
+	}
+
+	building, err := r.Registrar.GetBuildingByID(context.TODO(), buildingID)
 	if err != nil {
 		r.Logger.Error(err)
+		err = nil
 		return
 	}
 
@@ -2,11 +2,58 @@ package registry
 
 import (
 	"math/rand"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/bsdlp/apiutils"
 	"github.com/oklog/ulid"
 )
 
 func getULID() ulid.ULID {
 	return ulid.MustNew(ulid.Now(), rand.New(rand.NewSource(time.Now().UnixNano())))
 }
+
+// crockford base32, as emitted by ulid.ULID.String
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ParseBuildingID validates a building id, returning a 400 error if it is
+// missing or malformed
+func ParseBuildingID(s string) (buildingID string, err error) {
+	return parseULID(buildingIDAttributeName, s)
+}
+
+// ParseUnitID validates a unit id, returning a 400 error if it is missing or
+// malformed
+func ParseUnitID(s string) (unitID string, err error) {
+	return parseULID(unitIDAttributeName, s)
+}
+
+// ParseResidentID validates a resident id, returning a 400 error if it is
+// missing or malformed
+func ParseResidentID(s string) (residentID string, err error) {
+	return parseULID(residentIDAttributeName, s)
+}
+
+func parseULID(name, s string) (id string, err error) {
+	if s == "" {
+		err = apiutils.NewError(http.StatusBadRequest, name+" is required")
+		return
+	}
+
+	// ulid.Parse only checks the length. the first character carries the top
+	// bits of the timestamp, anything above 7 overflows 128 bits.
+	if len(s) != ulid.EncodedSize || s[0] > '7' {
+		err = apiutils.NewError(http.StatusBadRequest, name+" is malformed")
+		return
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(ulidAlphabet, c) {
+			err = apiutils.NewError(http.StatusBadRequest, name+" is malformed")
+			return
+		}
+	}
+
+	id = s
+	return
+}
@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bsdlp/apiutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIDs(t *testing.T) {
+	parsers := map[string]func(string) (string, error){
+		"building": ParseBuildingID,
+		"unit":     ParseUnitID,
+		"resident": ParseResidentID,
+	}
+
+	for entity, parse := range parsers {
+		t.Run(entity, func(t *testing.T) {
+			id := getULID().String()
+			parsed, err := parse(id)
+			assert.NoError(t, err)
+			assert.Equal(t, id, parsed)
+
+			for _, malformed := range []string{
+				"",
+				"nonexistent",
+				"12345",
+				id[:25],
+				id + "0",
+				"8" + id[1:],
+				"0" + id[1:25] + "U",
+				"0" + id[1:25] + "a",
+			} {
+				_, err := parse(malformed)
+				if assert.Error(t, err, malformed) {
+					assert.Equal(t, http.StatusBadRequest, err.(apiutils.Error).StatusCode(), malformed)
+				}
+			}
+		})
+	}
+}
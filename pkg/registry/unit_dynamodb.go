@@ -2,11 +2,13 @@ package registry
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/bsdlp/apiutils"
@@ -16,7 +18,8 @@ import (
 type dynamodbUnit struct {
 	ID         string `dynamodbav:"unit_id"`
 	Name       string
-	BuildingID string    `dynamodbav:"building_id"`
+	BuildingID string `dynamodbav:"building_id"`
+	Capacity   int64
 	Residents  []string  `dynamodb:",stringset"`
 	UpdatedAt  time.Time `dynamodbav:",unixtime"`
 }
@@ -54,8 +57,9 @@ func (dr *DynamoRegistrar) ListBuildingUnits(ctx context.Context, buildingID str
 	units = make([]*Unit, len(dbUnits))
 	for i, v := range dbUnits {
 		units[i] = &Unit{
-			ID:   v.ID,
-			Name: v.Name,
+			ID:       v.ID,
+			Name:     v.Name,
+			Capacity: v.Capacity,
 		}
 	}
 	return
@@ -63,11 +67,24 @@ func (dr *DynamoRegistrar) ListBuildingUnits(ctx context.Context, buildingID str
 
 // RegisterUnit implements Registrar
 func (dr *DynamoRegistrar) RegisterUnit(ctx context.Context, buildingID string, in *Unit) (unit *Unit, err error) {
+	if in.Capacity < 0 {
+		err = apiutils.NewError(http.StatusBadRequest, "capacity must not be negative")
+		return
+	}
+
+	if buildingID == "" {
+		buildingID, err = dr.defaultBuildingID(ctx)
+		if err != nil {
+			return
+		}
+	}
+
 	id := getULID().String()
 	item, err := dynamodbattribute.MarshalMap(&dynamodbUnit{
 		ID:         id,
 		Name:       in.Name,
 		BuildingID: buildingID,
+		Capacity:   in.Capacity,
 		UpdatedAt:  time.Now().UTC(),
 	})
 	if err != nil {
@@ -90,12 +107,65 @@ func (dr *DynamoRegistrar) RegisterUnit(ctx context.Context, buildingID string,
 	}
 
 	unit = &Unit{
-		ID:   id,
-		Name: in.Name,
+		ID:       id,
+		Name:     in.Name,
+		Capacity: in.Capacity,
 	}
 	return
 }
 
+// defaultBuildingID returns the building for units registered without one,
+// creating it on first use if configured to
+func (dr *DynamoRegistrar) defaultBuildingID(ctx context.Context) (buildingID string, err error) {
+	if dr.Config.DefaultBuildingID == "" {
+		err = apiutils.NewError(http.StatusBadRequest, "building_id is required")
+		return
+	}
+
+	if !dr.Config.CreateDefaultBuilding {
+		buildingID = dr.Config.DefaultBuildingID
+		return
+	}
+
+	// a failed attempt leaves the flag unset so the next call retries
+	dr.defaultBuildingMu.Lock()
+	defer dr.defaultBuildingMu.Unlock()
+	if dr.defaultBuildingCreated {
+		buildingID = dr.Config.DefaultBuildingID
+		return
+	}
+
+	item, err := dynamodbattribute.MarshalMap(&Building{
+		ID:   dr.Config.DefaultBuildingID,
+		Name: defaultBuildingName,
+	})
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	// leave the building alone if it already exists, it may have been renamed
+	_, err = dr.DB.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dr.Config.BuildingTableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#building_id)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#building_id": aws.String(buildingIDAttributeName),
+		},
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		err = nil
+	}
+	if err != nil {
+		err = errors.WithStack(err)
+		return
+	}
+
+	dr.defaultBuildingCreated = true
+	buildingID = dr.Config.DefaultBuildingID
+	return
+}
+
 // DeregisterUnit implements Registrar
 func (dr *DynamoRegistrar) DeregisterUnit(ctx context.Context, unitID string) (err error) {
 	params := &dynamodb.DeleteItemInput{
@@ -109,6 +179,60 @@ func (dr *DynamoRegistrar) DeregisterUnit(ctx context.Context, unitID string) (e
 	return
 }
 
+// ListUnitsByOccupancy implements Registrar
+func (dr *DynamoRegistrar) ListUnitsByOccupancy(ctx context.Context, minPct, maxPct float64) (units []*UnitStatus, err error) {
+	if math.IsNaN(minPct) || math.IsNaN(maxPct) || minPct < 0 || minPct > maxPct {
+		err = apiutils.NewError(http.StatusBadRequest, "invalid occupancy range")
+		return
+	}
+
+	units = []*UnitStatus{}
+	var unmarshalErr error
+	err = dr.DB.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName: aws.String(dr.Config.UnitTableName),
+	}, func(out *dynamodb.ScanOutput, lastPage bool) bool {
+		dbUnits := make([]*dynamodbUnit, aws.Int64Value(out.Count))
+		unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(out.Items, &dbUnits)
+		if unmarshalErr != nil {
+			return false
+		}
+
+		for _, v := range dbUnits {
+			// unlimited units have no meaningful occupancy
+			if v.Capacity <= 0 {
+				continue
+			}
+
+			residents := int64(len(v.Residents))
+			occupancy := float64(residents*100) / float64(v.Capacity)
+			if occupancy < minPct || occupancy > maxPct {
+				continue
+			}
+
+			units = append(units, &UnitStatus{
+				Unit: Unit{
+					ID:       v.ID,
+					Name:     v.Name,
+					Capacity: v.Capacity,
+				},
+				BuildingID: v.BuildingID,
+				Residents:  residents,
+				Occupancy:  occupancy,
+			})
+		}
+		return true
+	})
+	if err == nil {
+		err = unmarshalErr
+	}
+	if err != nil {
+		units = nil
+		err = errors.WithStack(err)
+		return
+	}
+	return
+}
+
 // ListUnitResidents implements Registrar
 func (dr *DynamoRegistrar) ListUnitResidents(ctx context.Context, unitID string) (residents []*Resident, err error) {
 	params := &dynamodb.GetItemInput{
@@ -134,6 +258,89 @@ func (dr *DynamoRegistrar) ListUnitResidents(ctx context.Context, unitID string)
 	return
 }
 
+// GetOccupancyForUnits implements Registrar
+func (dr *DynamoRegistrar) GetOccupancyForUnits(ctx context.Context, unitIDs []string) (occupancy map[string]int64, err error) {
+	occupancy = make(map[string]int64, len(unitIDs))
+
+	// BatchGetItem rejects duplicate keys
+	seen := make(map[string]bool, len(unitIDs))
+	keys := make([]map[string]*dynamodb.AttributeValue, 0, len(unitIDs))
+	for _, v := range unitIDs {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		keys = append(keys, map[string]*dynamodb.AttributeValue{
+			unitIDAttributeName: {S: aws.String(v)},
+		})
+	}
+
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > batchGetItemLimit {
+			n = batchGetItemLimit
+		}
+
+		requestItems := map[string]*dynamodb.KeysAndAttributes{
+			dr.Config.UnitTableName: {
+				Keys:                 keys[:n],
+				ProjectionExpression: aws.String("#unit_id, Residents"),
+				ExpressionAttributeNames: map[string]*string{
+					"#unit_id": aws.String(unitIDAttributeName),
+				},
+			},
+		}
+		keys = keys[n:]
+
+		delay := batchGetRetryDelay
+		for attempt := 1; len(requestItems) > 0; attempt++ {
+			if attempt > batchGetMaxAttempts {
+				occupancy = nil
+				err = apiutils.NewError(http.StatusServiceUnavailable, "units table is throttled, try again later")
+				return
+			}
+
+			if attempt > 1 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					occupancy = nil
+					err = errors.WithStack(ctx.Err())
+					return
+				case <-timer.C:
+				}
+				delay *= 2
+			}
+
+			var out *dynamodb.BatchGetItemOutput
+			out, err = dr.DB.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				occupancy = nil
+				err = errors.WithStack(err)
+				return
+			}
+
+			dbUnits := make([]*dynamodbUnit, len(out.Responses[dr.Config.UnitTableName]))
+			err = dynamodbattribute.UnmarshalListOfMaps(out.Responses[dr.Config.UnitTableName], &dbUnits)
+			if err != nil {
+				occupancy = nil
+				err = errors.WithStack(err)
+				return
+			}
+
+			for _, v := range dbUnits {
+				occupancy[v.ID] = int64(len(v.Residents))
+			}
+
+			requestItems = out.UnprocessedKeys
+		}
+	}
+	return
+}
+
 // MoveResidentIn implements Registrar
 func (dr *DynamoRegistrar) MoveResidentIn(ctx context.Context, residentID, unitID string) (err error) {
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"context"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,6 +87,21 @@ func TestIntegrationUnits(t *testing.T) {
 				}
 			})
 
+			t.Run("batch get unit occupancy", func(t *testing.T) {
+				unitIDs := make([]string, len(registeredUnits))
+				for i, v := range registeredUnits {
+					unitIDs[i] = v.ID
+				}
+
+				occupancy, err := testRegistrar.GetOccupancyForUnits(context.Background(), append(unitIDs, getULID().String()))
+				assert.NoError(t, err)
+				assert.Len(t, occupancy, len(registeredUnits))
+				assert.Equal(t, int64(2), occupancy[registeredUnits[0].ID])
+				for _, v := range registeredUnits[1:] {
+					assert.Equal(t, int64(0), occupancy[v.ID])
+				}
+			})
+
 			t.Run("move out one resident", func(t *testing.T) {
 				err := testRegistrar.MoveResidentOut(context.Background(), residents[1].ID, registeredUnits[0].ID)
 				assert.NoError(t, err)
@@ -120,3 +136,145 @@ func TestIntegrationUnits(t *testing.T) {
 		})
 	})
 }
+
+func TestIntegrationListUnitsByOccupancy(t *testing.T) {
+	registeredBuilding, err := testRegistrar.RegisterBuilding(context.Background(), &Building{
+		Name: getULID().String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err := testRegistrar.DeregisterBuilding(context.Background(), registeredBuilding.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// capacities of 0 (unlimited), 2 and 4, each with two residents
+	registeredUnits := make([]*Unit, 3)
+	for i := range registeredUnits {
+		unit, err := testRegistrar.RegisterUnit(context.Background(), registeredBuilding.ID, &Unit{
+			Name:     getULID().String(),
+			Capacity: int64(i * 2),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		registeredUnits[i] = unit
+
+		defer func() {
+			err := testRegistrar.DeregisterUnit(context.Background(), unit.ID)
+			assert.NoError(t, err)
+		}()
+
+		for j := 0; j < 2; j++ {
+			err = testRegistrar.MoveResidentIn(context.Background(), getULID().String(), unit.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	unitIDs := func(units []*UnitStatus) (ids []string) {
+		for _, v := range units {
+			ids = append(ids, v.ID)
+		}
+		return
+	}
+
+	t.Run("full units", func(t *testing.T) {
+		units, err := testRegistrar.ListUnitsByOccupancy(context.Background(), 100, 100)
+		assert.NoError(t, err)
+		ids := unitIDs(units)
+		assert.Contains(t, ids, registeredUnits[1].ID)
+		assert.NotContains(t, ids, registeredUnits[2].ID)
+	})
+
+	t.Run("half full units", func(t *testing.T) {
+		units, err := testRegistrar.ListUnitsByOccupancy(context.Background(), 0, 50)
+		assert.NoError(t, err)
+		ids := unitIDs(units)
+		assert.Contains(t, ids, registeredUnits[2].ID)
+		assert.NotContains(t, ids, registeredUnits[1].ID)
+	})
+
+	t.Run("unlimited units are excluded", func(t *testing.T) {
+		units, err := testRegistrar.ListUnitsByOccupancy(context.Background(), 0, math.Inf(1))
+		assert.NoError(t, err)
+		assert.NotContains(t, unitIDs(units), registeredUnits[0].ID)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := testRegistrar.ListUnitsByOccupancy(context.Background(), 80, 20)
+		assert.Error(t, err)
+	})
+}
+
+func TestIntegrationRegisterUnitDefaultBuilding(t *testing.T) {
+	t.Run("missing building without a default", func(t *testing.T) {
+		assert := assert.New(t)
+		unit, err := testRegistrar.RegisterUnit(context.Background(), "", &Unit{
+			Name: getULID().String(),
+		})
+		assert.Error(err)
+		assert.Nil(unit)
+	})
+
+	cfg := *testRegistrar.Config
+	cfg.DefaultBuildingID = getULID().String()
+	cfg.CreateDefaultBuilding = true
+	registrar := &DynamoRegistrar{
+		DB:     testRegistrar.DB,
+		Config: &cfg,
+	}
+
+	defer func() {
+		err := registrar.DeregisterBuilding(context.Background(), cfg.DefaultBuildingID)
+		assert.NoError(t, err)
+	}()
+
+	t.Run("missing building with a default", func(t *testing.T) {
+		assert := assert.New(t)
+		unit, err := registrar.RegisterUnit(context.Background(), "", &Unit{
+			Name: getULID().String(),
+		})
+		if !assert.NoError(err) {
+			return
+		}
+
+		defer func() {
+			err := registrar.DeregisterUnit(context.Background(), unit.ID)
+			assert.NoError(err)
+		}()
+
+		building, err := registrar.GetBuildingByID(context.Background(), cfg.DefaultBuildingID)
+		assert.NoError(err)
+		if assert.NotNil(building) {
+			assert.Equal(defaultBuildingName, building.Name)
+		}
+
+		units, err := registrar.ListBuildingUnits(context.Background(), cfg.DefaultBuildingID)
+		assert.NoError(err)
+		assert.Contains(units, unit)
+
+		t.Run("default building is reused", func(t *testing.T) {
+			another, err := registrar.RegisterUnit(context.Background(), "", &Unit{
+				Name: getULID().String(),
+			})
+			if !assert.NoError(err) {
+				return
+			}
+
+			defer func() {
+				err := registrar.DeregisterUnit(context.Background(), another.ID)
+				assert.NoError(err)
+			}()
+
+			units, err := registrar.ListBuildingUnits(context.Background(), cfg.DefaultBuildingID)
+			assert.NoError(err)
+			assert.Len(units, 2)
+		})
+	})
+}
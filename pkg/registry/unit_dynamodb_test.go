@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/bsdlp/apiutils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// throttlingDB leaves every key unprocessed for the first throttled calls,
+// then returns each unit with a single resident
+type throttlingDB struct {
+	dynamodbiface.DynamoDBAPI
+	throttled int
+	calls     int
+}
+
+func (db *throttlingDB) BatchGetItemWithContext(ctx aws.Context, in *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	db.calls++
+	if db.calls <= db.throttled {
+		return &dynamodb.BatchGetItemOutput{UnprocessedKeys: in.RequestItems}, nil
+	}
+
+	out := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{}}
+	for table, v := range in.RequestItems {
+		for _, key := range v.Keys {
+			out.Responses[table] = append(out.Responses[table], map[string]*dynamodb.AttributeValue{
+				unitIDAttributeName: key[unitIDAttributeName],
+				"Residents":         {SS: []*string{aws.String("resident")}},
+			})
+		}
+	}
+	return out, nil
+}
+
+func TestGetOccupancyForUnitsThrottled(t *testing.T) {
+	unitIDs := []string{getULID().String(), getULID().String()}
+
+	t.Run("retries unprocessed keys", func(t *testing.T) {
+		db := &throttlingDB{throttled: 2}
+		registrar := &DynamoRegistrar{DB: db, Config: testRegistrar.Config}
+		occupancy, err := registrar.GetOccupancyForUnits(context.Background(), unitIDs)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{unitIDs[0]: 1, unitIDs[1]: 1}, occupancy)
+		assert.Equal(t, 3, db.calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		db := &throttlingDB{throttled: batchGetMaxAttempts}
+		registrar := &DynamoRegistrar{DB: db, Config: testRegistrar.Config}
+		occupancy, err := registrar.GetOccupancyForUnits(context.Background(), unitIDs)
+		assert.Nil(t, occupancy)
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusServiceUnavailable, err.(apiutils.Error).StatusCode())
+		}
+		assert.Equal(t, batchGetMaxAttempts, db.calls)
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		db := &throttlingDB{throttled: batchGetMaxAttempts}
+		registrar := &DynamoRegistrar{DB: db, Config: testRegistrar.Config}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := registrar.GetOccupancyForUnits(ctx, unitIDs)
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+		assert.Equal(t, 1, db.calls)
+	})
+}
+
+// putCountingDB records PutItem calls and the last item written per table,
+// failing the first failBuildingPuts writes to the buildings table
+type putCountingDB struct {
+	dynamodbiface.DynamoDBAPI
+	failBuildingPuts int
+	puts             map[string]int
+	items            map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (db *putCountingDB) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	table := aws.StringValue(in.TableName)
+	db.puts[table]++
+	if db.items == nil {
+		db.items = map[string]map[string]*dynamodb.AttributeValue{}
+	}
+	db.items[table] = in.Item
+	if table == testRegistrar.Config.BuildingTableName && db.puts[table] <= db.failBuildingPuts {
+		return nil, errors.New("put failed")
+	}
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestRegisterUnitCreatesDefaultBuildingOnce(t *testing.T) {
+	cfg := *testRegistrar.Config
+	cfg.DefaultBuildingID = getULID().String()
+	cfg.CreateDefaultBuilding = true
+	db := &putCountingDB{failBuildingPuts: 1, puts: map[string]int{}}
+	registrar := &DynamoRegistrar{DB: db, Config: &cfg}
+
+	_, err := registrar.RegisterUnit(context.Background(), "", &Unit{Name: "1"})
+	assert.Error(t, err, "failed creation should surface")
+
+	for i := 0; i < 3; i++ {
+		_, err := registrar.RegisterUnit(context.Background(), "", &Unit{Name: "1"})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, db.puts[cfg.BuildingTableName], "creation is retried once after failing, then skipped")
+	assert.Equal(t, 3, db.puts[cfg.UnitTableName])
+}
+
+func TestRegisterUnitWithoutBuilding(t *testing.T) {
+	t.Run("no default building configured", func(t *testing.T) {
+		cfg := *testRegistrar.Config
+		db := &putCountingDB{puts: map[string]int{}}
+		registrar := &DynamoRegistrar{DB: db, Config: &cfg}
+
+		unit, err := registrar.RegisterUnit(context.Background(), "", &Unit{Name: "1"})
+		assert.Nil(t, unit)
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(apiutils.Error).StatusCode())
+		}
+		assert.Equal(t, 0, db.puts[cfg.BuildingTableName])
+		assert.Equal(t, 0, db.puts[cfg.UnitTableName])
+	})
+
+	t.Run("default building without creating it", func(t *testing.T) {
+		cfg := *testRegistrar.Config
+		cfg.DefaultBuildingID = getULID().String()
+		db := &putCountingDB{puts: map[string]int{}}
+		registrar := &DynamoRegistrar{DB: db, Config: &cfg}
+
+		unit, err := registrar.RegisterUnit(context.Background(), "", &Unit{Name: "1"})
+		assert.NoError(t, err)
+		assert.NotNil(t, unit)
+		assert.Equal(t, 0, db.puts[cfg.BuildingTableName])
+		assert.Equal(t, 1, db.puts[cfg.UnitTableName])
+		assert.Equal(t, cfg.DefaultBuildingID, aws.StringValue(db.items[cfg.UnitTableName][buildingIDAttributeName].S))
+	})
+}